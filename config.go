@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// CONFIG
+
+// WatchBlock is a single rule parsed from a config file (-f): a glob pattern
+// for the paths it watches, the command to run when one of them changes, and
+// optional per-block overrides of the global delay and timeout.
+type WatchBlock struct {
+	Pattern string
+	Command string
+	Delay   time.Duration
+	Timeout time.Duration
+}
+
+// parseConfig parses a modd-inspired config file of blocks, each pairing a
+// glob pattern with the command to run when a matching path changes:
+//
+//	a/** {
+//	    prep: echo $WATCH_MODS
+//	    delay: 2
+//	    timeout: 30
+//	}
+//
+// Blank lines and lines starting with # are ignored. delay and timeout are
+// seconds; when omitted a block uses the global -d/-t values.
+func parseConfig(path string) ([]*WatchBlock, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening config %q: %w", path, err)
+	}
+	defer f.Close()
+
+	var blocks []*WatchBlock
+	var cur *WatchBlock
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+
+		case cur == nil:
+			if !strings.HasSuffix(line, "{") {
+				return nil, fmt.Errorf("%s:%d: expected %q, got %q", path, lineNo, "<pattern> {", line)
+			}
+			cur = &WatchBlock{Pattern: strings.TrimSpace(strings.TrimSuffix(line, "{"))}
+
+		case line == "}":
+			blocks = append(blocks, cur)
+			cur = nil
+
+		default:
+			key, val, ok := strings.Cut(line, ":")
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: expected %q, got %q", path, lineNo, "key: value", line)
+			}
+			val = strings.TrimSpace(val)
+			switch strings.TrimSpace(key) {
+			case "prep":
+				cur.Command = val
+			case "delay":
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: invalid delay %q: %v", path, lineNo, val, err)
+				}
+				cur.Delay = time.Duration(n) * time.Second
+			case "timeout":
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: invalid timeout %q: %v", path, lineNo, val, err)
+				}
+				cur.Timeout = time.Duration(n) * time.Second
+			default:
+				return nil, fmt.Errorf("%s:%d: unknown key %q", path, lineNo, key)
+			}
+		}
+	}
+	if cur != nil {
+		return nil, fmt.Errorf("%s: unterminated block %q", path, cur.Pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading config %q: %w", path, err)
+	}
+
+	return blocks, nil
+}
+
+// runConfig runs watch in config-file mode: every block in path is watched
+// and run independently, with its latest output shown in its own pane.
+func runConfig(path string) {
+	blocks, err := parseConfig(path)
+	if err != nil {
+		bail("%v", err)
+	}
+	if len(blocks) == 0 {
+		bail("no blocks in config %q", path)
+	}
+
+	screen := newPaneScreen()
+	results := make(chan blockResult)
+	for _, b := range blocks {
+		if b.Delay == 0 {
+			b.Delay = time.Duration(*delay) * time.Second
+		}
+		if b.Timeout == 0 {
+			b.Timeout = time.Duration(*timeout) * time.Second
+		}
+		screen.Pane(b.Pattern)
+		go newBlockRunner(b).watch(results)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, os.Kill)
+	go func() {
+		<-stop
+		os.Exit(0)
+	}()
+
+	for res := range results {
+		status := ""
+		if err, ok := res.cmd.err.(*exec.ExitError); ok {
+			status = fmt.Sprintf("exit code %v", err.ExitCode())
+		} else if res.cmd.err != nil {
+			status = res.cmd.err.Error()
+		}
+		screen.Update(res.pattern, status, res.cmd.buf.String())
+		screen.Render()
+	}
+}
+
+// -----------------------------------------------------------------------------
+// BLOCK RUNNER
+
+// blockRunner drives a single WatchBlock: it periodically checks the
+// block's WatchPaths for changes and runs the block's command (with
+// WATCH_MODS set to the changed files) whenever one of them changes.
+type blockRunner struct {
+	block *WatchBlock
+	cmd   *WatchCommand
+	paths *WatchPaths
+}
+
+// blockResult is sent on a blockRunner's results channel after it runs its
+// command.
+type blockResult struct {
+	pattern string
+	mods    []string
+	cmd     *WatchCommand
+}
+
+func newBlockRunner(b *WatchBlock) *blockRunner {
+	return &blockRunner{
+		block: b,
+		cmd:   &WatchCommand{name: "sh", args: []string{"-c", b.Command}, timeout: b.Timeout},
+		paths: NewWatchPaths(b.Pattern, ""),
+	}
+}
+
+// watch checks r's WatchPaths every r.block.Delay, running r's command and
+// sending the result on results whenever matched files changed.
+func (r *blockRunner) watch(results chan<- blockResult) {
+	for {
+		time.Sleep(r.block.Delay)
+		r.paths.update()
+		mods := r.paths.Changed()
+		if len(mods) == 0 {
+			continue
+		}
+		r.run(mods)
+		results <- blockResult{pattern: r.block.Pattern, mods: mods, cmd: r.cmd}
+	}
+}
+
+// run executes the block's command with WATCH_MODS set to a space separated
+// list of mods.
+func (r *blockRunner) run(mods []string) {
+	r.cmd.env = []string{"WATCH_MODS=" + strings.Join(mods, " ")}
+	r.cmd.run()
+}
+
+// -----------------------------------------------------------------------------
+// GLOB
+
+// matchGlob reports whether path matches pattern, where pattern may use "**"
+// to match any number of path segments (including zero), in addition to the
+// usual single-segment wildcards supported by path/filepath.Match.
+func matchGlob(pattern, path string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(path, "/"))
+}
+
+func matchSegments(pat, path []string) bool {
+	if len(pat) == 0 {
+		return len(path) == 0
+	}
+	if pat[0] == "**" {
+		if matchSegments(pat[1:], path) {
+			return true
+		}
+		return len(path) > 0 && matchSegments(pat, path[1:])
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, err := filepath.Match(pat[0], path[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pat[1:], path[1:])
+}
+
+// globBase returns the longest literal prefix of pattern, i.e. the path
+// that must be watched (recursively) to see every path the pattern could
+// match. A pattern with no wildcards is returned whole, so the result may
+// name a file rather than a directory.
+func globBase(pattern string) string {
+	var base []string
+	for _, s := range strings.Split(pattern, "/") {
+		if strings.ContainsAny(s, "*?[") {
+			break
+		}
+		base = append(base, s)
+	}
+	if len(base) == 0 || (len(base) == 1 && base[0] == "") {
+		if strings.HasPrefix(pattern, "/") {
+			return "/"
+		}
+		return "."
+	}
+	joined := filepath.Join(base...)
+	// filepath.Join drops the leading empty segment Split produces for an
+	// absolute pattern, so restore it rather than watching the wrong,
+	// cwd-relative directory.
+	if strings.HasPrefix(pattern, "/") && !strings.HasPrefix(joined, "/") {
+		joined = "/" + joined
+	}
+	return joined
+}
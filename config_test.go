@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"a/*.go", "a/b.go", true},
+		{"a/*.go", "a/b/c.go", false},
+		{"a/**/*.go", "a/b.go", true},
+		{"a/**/*.go", "a/b/c.go", true},
+		{"a/**/*.go", "a/b/c/d.go", true},
+		{"a/**", "a", true},
+		{"a/**", "a/b/c", true},
+		{"**/*.go", "x/y/z.go", true},
+		{"**/*.go", "z.go", true},
+		{"a/*.go", "b/b.go", false},
+		{"a/*.go", "a/b.txt", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGlobBase(t *testing.T) {
+	tests := []struct {
+		pattern, want string
+	}{
+		{"a/b/*.go", "a/b"},
+		{"a/**/*.go", "a"},
+		{"**/*.go", "."},
+		{"*.go", "."},
+		{"a/b/c", "a/b/c"},
+		{"/tmp/foo/*.go", "/tmp/foo"},
+		{"/**/*.go", "/"},
+		{"/a", "/a"},
+	}
+	for _, tt := range tests {
+		if got := globBase(tt.pattern); got != tt.want {
+			t.Errorf("globBase(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
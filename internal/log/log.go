@@ -0,0 +1,116 @@
+// Package log provides the small leveled logger used throughout watch, with
+// independent trace categories toggled by the WATCHTRACE environment
+// variable (e.g. WATCHTRACE=paths,cmd,screen or WATCHTRACE=all), similar to
+// syncthing's STTRACE. Output is writer-pluggable so tests can capture it.
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is a logging severity.
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelWarn
+	LevelError
+	LevelDebug
+	LevelTrace
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	case LevelDebug:
+		return "DEBUG"
+	case LevelTrace:
+		return "TRACE"
+	default:
+		return "INFO"
+	}
+}
+
+var (
+	mu      sync.Mutex
+	out     io.Writer = os.Stderr
+	verbose bool
+	trace   = parseTrace(os.Getenv("WATCHTRACE"))
+)
+
+// SetOutput redirects all log output to w. Defaults to os.Stderr; tests can
+// pass a buffer to capture output.
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = w
+}
+
+// SetVerbose enables Warnf/Debugf output. Infof/Errorf and enabled Tracef
+// categories are always printed regardless.
+func SetVerbose(v bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	verbose = v
+}
+
+func parseTrace(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, cat := range strings.Split(s, ",") {
+		if cat = strings.TrimSpace(cat); cat != "" {
+			m[cat] = true
+		}
+	}
+	return m
+}
+
+// Enabled reports whether category is enabled via WATCHTRACE, either by
+// name or via "all".
+func Enabled(category string) bool {
+	return trace["all"] || trace[category]
+}
+
+func logf(level Level, msg string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	fmt.Fprintf(out, "%s %-5s %s\n", time.Now().Format("15:04:05.000"), level, fmt.Sprintf(msg, args...))
+}
+
+// Infof always prints.
+func Infof(msg string, args ...any) {
+	logf(LevelInfo, msg, args...)
+}
+
+// Errorf always prints.
+func Errorf(msg string, args ...any) {
+	logf(LevelError, msg, args...)
+}
+
+// Warnf prints only when verbose output is enabled (see SetVerbose).
+func Warnf(msg string, args ...any) {
+	if verbose {
+		logf(LevelWarn, msg, args...)
+	}
+}
+
+// Debugf prints only when verbose output is enabled (see SetVerbose).
+func Debugf(msg string, args ...any) {
+	if verbose {
+		logf(LevelDebug, msg, args...)
+	}
+}
+
+// Tracef prints only when category is enabled via WATCHTRACE.
+func Tracef(category, msg string, args ...any) {
+	if Enabled(category) {
+		logf(LevelTrace, "["+category+"] "+msg, args...)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	wlog "github.com/MHmorgan/watch/internal/log"
+)
+
+// -----------------------------------------------------------------------------
+// FSNOTIFY WATCHER
+
+// fsWatcher drives the main loop from filesystem change events instead of
+// sleeping and rehashing WatchPaths on every tick. It watches the same files
+// and directories as WatchPaths, adding new subdirectories as they appear and
+// dropping them again once they're removed. Bursts of events are coalesced
+// into a single signal on changed, debounced by fw.debounce.
+type fsWatcher struct {
+	w        *fsnotify.Watcher
+	paths    *WatchPaths
+	debounce time.Duration
+	changed  chan struct{}
+
+	mu      sync.Mutex
+	pending []string // matched paths accumulated since the last Drain
+}
+
+// newFSWatcher creates a fsWatcher for paths, registering all of its base
+// directories (recursively) with the underlying fsnotify.Watcher.
+func newFSWatcher(paths *WatchPaths, debounce time.Duration) (*fsWatcher, error) {
+	if paths == nil {
+		return nil, errors.New("no paths to watch")
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating fsnotify watcher: %w", err)
+	}
+
+	fw := &fsWatcher{w: w, paths: paths, debounce: debounce, changed: make(chan struct{}, 1)}
+
+	for _, base := range paths.bases {
+		if err := fw.addDir(base); err != nil {
+			return nil, err
+		}
+	}
+
+	go fw.run()
+	return fw, nil
+}
+
+// addDir registers dir and all its subdirectories with the watcher. If dir is
+// not itself a directory (e.g. a literal, non-glob include pattern such as
+// -p somefile.go), its parent directory is watched instead, since editors
+// commonly replace a file via rename rather than writing it in place.
+func (fw *fsWatcher) addDir(dir string) error {
+	if info, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("watching %q: %w", dir, err)
+	} else if !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	return filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if err := fw.w.Add(path); err != nil {
+				return fmt.Errorf("watching %q: %w", path, err)
+			}
+		}
+		return nil
+	})
+}
+
+// run drains fsnotify events until the watcher is closed, keeping the
+// watched directory set up to date and signalling changed whenever events
+// have been quiet for fw.debounce.
+func (fw *fsWatcher) run() {
+	var timer *time.Timer
+	for {
+		select {
+		case ev, ok := <-fw.w.Events:
+			if !ok {
+				return
+			}
+			if ev.Op&fsnotify.Create == fsnotify.Create {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					fw.addDir(ev.Name)
+					continue
+				}
+			}
+			if ev.Op&fsnotify.Remove == fsnotify.Remove || ev.Op&fsnotify.Rename == fsnotify.Rename {
+				fw.w.Remove(ev.Name)
+			}
+			if !fw.paths.matches(ev.Name) {
+				continue
+			}
+			wlog.Tracef("fsnotify", "%s %s", ev.Op, ev.Name)
+
+			fw.mu.Lock()
+			fw.pending = append(fw.pending, ev.Name)
+			fw.mu.Unlock()
+
+			if timer == nil {
+				timer = time.AfterFunc(fw.debounce, fw.signal)
+			} else {
+				timer.Reset(fw.debounce)
+			}
+		case err, ok := <-fw.w.Errors:
+			if !ok {
+				return
+			}
+			warn("fsnotify: %v", err)
+		}
+	}
+}
+
+// changedCh returns fw.changed, or nil if fw itself is nil, so it can be
+// selected on unconditionally.
+func (fw *fsWatcher) changedCh() <-chan struct{} {
+	if fw == nil {
+		return nil
+	}
+	return fw.changed
+}
+
+// Drain returns the paths that triggered the most recent signal and clears
+// them, so the next signal starts from an empty set.
+func (fw *fsWatcher) Drain() []string {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	paths := fw.pending
+	fw.pending = nil
+	return paths
+}
+
+// signal notifies changed, dropping the signal rather than blocking if one
+// is already pending.
+func (fw *fsWatcher) signal() {
+	select {
+	case fw.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the underlying fsnotify.Watcher.
+func (fw *fsWatcher) Close() error {
+	return fw.w.Close()
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// -----------------------------------------------------------------------------
+// TCELL SCREEN
+
+// forceRunner is implemented by screens that can ask the main loop to rerun
+// the command outside of the normal change detection, e.g. via a hotkey.
+type forceRunner interface {
+	ForceRunChan() <-chan struct{}
+}
+
+// tcellScreen is an interactive WatchScreen backed by tcell: a header bar
+// (name, timestamp, status, exit code), a scrollable, line-wrapped output
+// pane, and a key bindings row. Unlike plain/vt100 it drives its own event
+// loop and a render ticker, and exposes pause state and a force-run channel
+// so the main loop can react to hotkeys ('r' forces a rerun even when
+// paths.hasChanged() is false).
+type tcellScreen struct {
+	mu       sync.Mutex
+	scr      tcell.Screen
+	name     string
+	status   string
+	lines    []string // output, split on newlines, oldest first
+	scroll   int      // lines scrolled up from the bottom; 0 = pinned to bottom
+	paused   bool
+	search   string
+	inSearch bool
+
+	forceRun chan struct{}
+	done     chan struct{}
+}
+
+const maxScrollback = 10000
+
+func (s *tcellScreen) Setup() {
+	s.forceRun = make(chan struct{}, 1)
+	s.done = make(chan struct{})
+
+	scr, err := tcell.NewScreen()
+	if err != nil {
+		bail("tcell: %v", err)
+	}
+	if err := scr.Init(); err != nil {
+		bail("tcell: %v", err)
+	}
+	s.scr = scr
+
+	go s.pollEvents()
+	go s.renderLoop()
+}
+
+func (s *tcellScreen) Teardown() {
+	close(s.done)
+	if s.scr != nil {
+		s.scr.Fini()
+	}
+}
+
+func (s *tcellScreen) Name(name string) {
+	s.mu.Lock()
+	s.name = name
+	s.mu.Unlock()
+}
+
+func (s *tcellScreen) Status(txt string, a ...any) {
+	s.mu.Lock()
+	s.status = fmt.Sprintf(txt, a...)
+	s.mu.Unlock()
+}
+
+// Write appends b to the scrollback, trims it to maxScrollback lines, and
+// pins the view back to the bottom.
+func (s *tcellScreen) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	s.lines = append(s.lines, strings.Split(strings.TrimRight(string(b), "\n"), "\n")...)
+	if over := len(s.lines) - maxScrollback; over > 0 {
+		s.lines = s.lines[over:]
+	}
+	s.scroll = 0
+	s.mu.Unlock()
+	return len(b), nil
+}
+
+// ForceRunChan returns the channel the main loop selects on to let 'r'
+// bypass normal change detection.
+func (s *tcellScreen) ForceRunChan() <-chan struct{} {
+	return s.forceRun
+}
+
+// Paused reports whether output is currently paused (space toggles it).
+func (s *tcellScreen) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// pollEvents handles key presses: q quits, space pauses/resumes, r forces a
+// rerun, / starts an incremental search, PgUp/PgDn scroll the output pane.
+func (s *tcellScreen) pollEvents() {
+	for {
+		ev := s.scr.PollEvent()
+		if ev == nil {
+			return
+		}
+		switch ev := ev.(type) {
+		case *tcell.EventResize:
+			s.scr.Sync()
+		case *tcell.EventKey:
+			s.handleKey(ev)
+		}
+	}
+}
+
+func (s *tcellScreen) handleKey(ev *tcell.EventKey) {
+	s.mu.Lock()
+	if s.inSearch {
+		switch ev.Key() {
+		case tcell.KeyEnter, tcell.KeyEscape:
+			s.inSearch = false
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			if len(s.search) > 0 {
+				s.search = s.search[:len(s.search)-1]
+			}
+		case tcell.KeyRune:
+			s.search += string(ev.Rune())
+		}
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	switch {
+	case ev.Key() == tcell.KeyRune && ev.Rune() == 'q':
+		s.Teardown()
+		os.Exit(0)
+	case ev.Key() == tcell.KeyRune && ev.Rune() == ' ':
+		s.mu.Lock()
+		s.paused = !s.paused
+		s.mu.Unlock()
+	case ev.Key() == tcell.KeyRune && ev.Rune() == 'r':
+		select {
+		case s.forceRun <- struct{}{}:
+		default:
+		}
+	case ev.Key() == tcell.KeyRune && ev.Rune() == '/':
+		s.mu.Lock()
+		s.inSearch = true
+		s.search = ""
+		s.mu.Unlock()
+	case ev.Key() == tcell.KeyPgUp:
+		s.mu.Lock()
+		s.scroll += 10
+		s.mu.Unlock()
+	case ev.Key() == tcell.KeyPgDn:
+		s.mu.Lock()
+		if s.scroll -= 10; s.scroll < 0 {
+			s.scroll = 0
+		}
+		s.mu.Unlock()
+	}
+}
+
+// renderLoop redraws the screen on a ticker, so scrollback/pause state
+// changes show up promptly without waiting for the next Write.
+func (s *tcellScreen) renderLoop() {
+	tick := time.NewTicker(100 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-tick.C:
+			s.draw()
+		}
+	}
+}
+
+func (s *tcellScreen) draw() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, h := s.scr.Size()
+	s.scr.Clear()
+
+	header := fmt.Sprintf("WATCH %s [%s", s.name, timestamp())
+	if s.status != "" {
+		header += " " + s.status
+	}
+	header += "]"
+	if s.paused {
+		header += " [PAUSED]"
+	}
+	drawLine(s.scr, 0, 0, w, header, tcell.StyleDefault.Bold(true))
+
+	wrapped := wrapLines(s.lines, w)
+	if s.inSearch && s.search != "" {
+		wrapped = filterLines(wrapped, s.search)
+	}
+
+	paneHeight := h - 3
+	start := len(wrapped) - paneHeight - s.scroll
+	if start < 0 {
+		start = 0
+	}
+	end := start + paneHeight
+	if end > len(wrapped) {
+		end = len(wrapped)
+	}
+	for i, line := range wrapped[start:end] {
+		drawLine(s.scr, 0, 2+i, w, line, tcell.StyleDefault)
+	}
+
+	keys := "q quit  space pause/resume  r rerun  / search  PgUp/PgDn scroll"
+	if s.inSearch {
+		keys = "search: " + s.search
+	}
+	drawLine(s.scr, 0, h-1, w, keys, tcell.StyleDefault.Reverse(true))
+
+	s.scr.Show()
+}
+
+func drawLine(scr tcell.Screen, x, y, w int, text string, style tcell.Style) {
+	for i, r := range []rune(text) {
+		if i >= w {
+			break
+		}
+		scr.SetContent(x+i, y, r, nil, style)
+	}
+}
+
+// wrapLines wraps every line in lines to at most w runes, preserving order.
+func wrapLines(lines []string, w int) []string {
+	if w <= 0 {
+		return lines
+	}
+	var out []string
+	for _, line := range lines {
+		runes := []rune(line)
+		for len(runes) > w {
+			out = append(out, string(runes[:w]))
+			runes = runes[w:]
+		}
+		out = append(out, string(runes))
+	}
+	return out
+}
+
+// filterLines returns only the lines containing substr.
+func filterLines(lines []string, substr string) []string {
+	var out []string
+	for _, line := range lines {
+		if strings.Contains(line, substr) {
+			out = append(out, line)
+		}
+	}
+	return out
+}
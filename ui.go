@@ -3,12 +3,18 @@ package main
 import (
 	"fmt"
 	"io"
+	"strings"
 	"time"
+
+	wlog "github.com/MHmorgan/watch/internal/log"
 )
 
 var screens = map[string]WatchScreen{
-	"plain": &plainScreen{},
-	"vt100": &vt100Screen{},
+	"plain":      &plainScreen{},
+	"vt100":      &vt100Screen{},
+	"tcell":      &tcellScreen{},
+	"diff":       &diffScreen{},
+	"vt100-diff": &diffScreen{clear: true},
 }
 
 const (
@@ -17,6 +23,9 @@ const (
 	RESET = "\033[0m"
 	HIDE  = "\033[?25l"
 	SHOW  = "\033[?25h"
+	GREEN = "\033[32m"
+	RED   = "\033[31m"
+	DIM   = "\033[2m"
 )
 
 type WatchScreen interface {
@@ -27,6 +36,15 @@ type WatchScreen interface {
 	Teardown()
 }
 
+// diffWriter is implemented by screens that render a line-level diff
+// instead of the raw command output. When the active screen implements it,
+// the main loop calls WriteDiff with the WatchCommand's diff op stream
+// instead of Write-ing cmd.buf directly, except on the first run, whose
+// output has nothing to diff against and is written with Write.
+type diffWriter interface {
+	WriteDiff(ops []diffOp) (int, error)
+}
+
 // -----------------------------------------------------------------------------
 // PLAIN SCREEN
 
@@ -36,6 +54,7 @@ type plainScreen struct {
 }
 
 func (s *plainScreen) Write(b []byte) (n int, err error) {
+	wlog.Tracef("screen", "plain: writing %d bytes", len(b))
 	header := fmt.Sprintf("WATCH %s [%s", s.name, timestamp())
 	if s.status != "" {
 		header += " " + s.status
@@ -89,6 +108,137 @@ func (s *vt100Screen) Teardown() {
 	fmt.Print(SHOW)
 }
 
+// -----------------------------------------------------------------------------
+// PANE SCREEN
+
+// pane holds the latest status and output of a single config-file block.
+type pane struct {
+	name   string
+	status string
+	output string
+}
+
+// paneScreen renders one pane per config-file block (in first-seen order),
+// each showing that block's latest status and output independently. It is
+// used for config-file mode (-f), where several WatchCommands run
+// concurrently against different blocks.
+type paneScreen struct {
+	order []string
+	panes map[string]*pane
+}
+
+func newPaneScreen() *paneScreen {
+	return &paneScreen{panes: make(map[string]*pane)}
+}
+
+// Pane registers name as a pane, in first-seen order, if it isn't already
+// one.
+func (s *paneScreen) Pane(name string) {
+	if _, ok := s.panes[name]; !ok {
+		s.panes[name] = &pane{name: name}
+		s.order = append(s.order, name)
+	}
+}
+
+// Update sets the status and output of name's pane.
+func (s *paneScreen) Update(name, status, output string) {
+	s.Pane(name)
+	p := s.panes[name]
+	p.status = status
+	p.output = output
+}
+
+// Render reprints every pane's latest header and output, in first-seen
+// order.
+func (s *paneScreen) Render() {
+	fmt.Print(CLEAR)
+	for _, name := range s.order {
+		p := s.panes[name]
+		header := fmt.Sprintf("%sWATCH %s%s [%s", BOLD, p.name, RESET, timestamp())
+		if p.status != "" {
+			header += " " + p.status
+		}
+		header += "]"
+		fmt.Printf("%s\n\n%s\n", header, p.output)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// DIFF SCREEN
+
+// diffScreen renders a line-level diff between consecutive command outputs
+// (see WatchCommand.diff) instead of reprinting the whole buffer: additions
+// in green, deletions in red, unchanged context dimmed. With clear set
+// (screen type "vt100-diff") it also clears the screen and hides the
+// cursor, like vt100Screen.
+type diffScreen struct {
+	name   string
+	status string
+	clear  bool
+}
+
+// Write prints b as-is, without a header. The main loop uses it for the
+// first run's output, which has no previous output to diff against.
+func (s *diffScreen) Write(b []byte) (int, error) {
+	return fmt.Print(string(b))
+}
+
+// WriteDiff renders ops with additions in green, deletions in red and
+// unchanged context dimmed, and shows the number of changed lines in the
+// header.
+func (s *diffScreen) WriteDiff(ops []diffOp) (int, error) {
+	changed := 0
+	for _, op := range ops {
+		if op.kind != '=' {
+			changed++
+		}
+	}
+
+	header := fmt.Sprintf("WATCH %s [%s", s.name, timestamp())
+	if s.status != "" {
+		header += " " + s.status
+	}
+	header += fmt.Sprintf(" %d changed]", changed)
+
+	var out strings.Builder
+	if s.clear {
+		out.WriteString(CLEAR)
+	}
+	out.WriteString(header)
+	out.WriteString("\n\n")
+	for _, op := range ops {
+		switch op.kind {
+		case '+':
+			fmt.Fprintf(&out, "%s+ %s%s\n", GREEN, op.line, RESET)
+		case '-':
+			fmt.Fprintf(&out, "%s- %s%s\n", RED, op.line, RESET)
+		default:
+			fmt.Fprintf(&out, "%s  %s%s\n", DIM, op.line, RESET)
+		}
+	}
+	return fmt.Print(out.String())
+}
+
+func (s *diffScreen) Status(txt string, a ...any) {
+	s.status = fmt.Sprintf(txt, a...)
+}
+
+func (s *diffScreen) Name(name string) {
+	s.name = name
+}
+
+func (s *diffScreen) Setup() {
+	if s.clear {
+		fmt.Print(HIDE)
+	}
+}
+
+func (s *diffScreen) Teardown() {
+	if s.clear {
+		fmt.Print(SHOW)
+	}
+}
+
 // -----------------------------------------------------------------------------
 // HELPERS
 
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"hash"
 	"hash/adler32"
 	"io/fs"
 	"os"
@@ -14,7 +13,10 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
+
+	wlog "github.com/MHmorgan/watch/internal/log"
 )
 
 var (
@@ -22,8 +24,11 @@ var (
 	verbose = flag.Bool("v", false, "verbose output")
 	delay   = flag.Int("d", 1, "delay in seconds between commands")
 	timeout = flag.Int("t", 60, "WatchCommand timeout in seconds")
-	pathStr = flag.String("p", "", "paths to watch for changes (optional)")
-	scrType = flag.String("s", "plain", "screen type (plain, vt100)")
+	pathStr = flag.String("p", "", "glob patterns of paths to watch for changes (optional)")
+	exclStr = flag.String("x", "", "glob patterns to exclude from -p (optional)")
+	scrType = flag.String("s", "plain", "screen type (plain, vt100, tcell, diff, vt100-diff)")
+	watchW  = flag.String("w", "poll", "watch mode (poll, fsnotify)")
+	cfgPath = flag.String("f", "", "config file with multiple named blocks, each with its own command (optional)")
 )
 
 const usage = `               _       _     
@@ -37,10 +42,18 @@ Usage: watch [options] command [args...]
 Watch a command and its output. There is a delay between commands (-d)
 and if a timeout (-t) is reached then watch will exit.
 
-The paths (-p) are a space separated list of paths to watch for changes.
+The paths (-p) are a space separated list of glob patterns of paths to
+watch for changes, e.g. -p 'src/**/*.go'. A "**" segment matches any
+number of path segments. Matches can be narrowed with -x, a space
+separated list of glob patterns to exclude, e.g. -x 'vendor/**'.
 Directories are searched recursively. When no changes are detected the
 command is not run.
 
+Each watched file is first checked cheaply (mtime, size, inode); only
+files whose stat changed are read and hashed, so unchanged trees cost
+a stat per file rather than a read. The files found to have changed are
+exposed to the command via $WATCH_CHANGED.
+
 The screen type determines how the output is displayed. The default, plain,
 will just print the output to stdout with no formatting.
 
@@ -49,12 +62,52 @@ Screen types
         Plain text output.
     vt100
         VT100 terminal output.
+    tcell
+        Interactive terminal UI with scrollback, pause/resume, forced
+        re-run and incremental search. Keys: q quit, space pause/resume,
+        r force a rerun, / search, PgUp/PgDn scroll.
+    diff
+        Like plain, but prints a line-level diff between consecutive
+        outputs: additions in green, deletions in red, unchanged context
+        dimmed. The changed-line count appears in the header.
+    vt100-diff
+        diff, with the screen cleared before each redraw.
+
+The watch mode (-w) determines how changes to the watched paths (-p) are
+detected.
+
+Watch modes
+    poll
+        Stat every watched path on each tick, delay (-d) seconds apart,
+        rehashing only files whose stat changed. Works everywhere.
+    fsnotify
+        Block on filesystem change events instead of polling, coalesced
+        with a debounce window equal to the delay (-d). Falls back to
+        poll on filesystems without inotify/kqueue support.
+
+Instead of a single command, a config file (-f) can define multiple named
+blocks, each with its own glob pattern, command and delay/timeout
+overrides:
+
+    a/** {
+        prep: go test ./...
+        delay: 2
+    }
+
+Blocks are watched and run independently, and each gets its own pane in
+the output. The changed files matching a block's pattern are exposed to
+its command via $WATCH_MODS.
+
+Set WATCHTRACE to a comma separated list of trace categories (paths, cmd,
+screen, ...), or "all", to print detailed per-subsystem trace logging in
+addition to -v's warnings and debug output.
 
 Options:
 `
 
 func main() {
 	flag.Parse()
+	wlog.SetVerbose(*verbose)
 
 	if *help {
 		fmt.Print(usage)
@@ -62,6 +115,11 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *cfgPath != "" {
+		runConfig(*cfgPath)
+		return
+	}
+
 	if flag.NArg() < 1 {
 		bail("No command specified.")
 	}
@@ -72,7 +130,24 @@ func main() {
 		timeout: time.Duration(*timeout) * time.Second,
 	}
 	delay := time.Duration(*delay) * time.Second
-	paths := NewWatchPaths(*pathStr)
+	paths := NewWatchPaths(*pathStr, *exclStr)
+
+	var fsw *fsWatcher
+	switch *watchW {
+	case "poll":
+		// fall through to the polling loop below
+	case "fsnotify":
+		var err error
+		fsw, err = newFSWatcher(paths, delay)
+		if err != nil {
+			warn("fsnotify: %v, falling back to poll", err)
+			fsw = nil
+		} else {
+			defer fsw.Close()
+		}
+	default:
+		bail("unknown watch mode: %v", *watchW)
+	}
 
 	screen, ok := screens[*scrType]
 	if !ok {
@@ -90,20 +165,61 @@ func main() {
 		os.Exit(0)
 	}()
 
-	debug("watching %q", flag.Args())
-	debug("delay %v", delay)
-	debug("timeout %v", cmd.timeout)
+	debug("main", "watching %q", flag.Args())
+	debug("main", "delay %v", delay)
+	debug("main", "timeout %v", cmd.timeout)
 
 	// WATCH
 
+	var forceRun <-chan struct{}
+	if fr, ok := screen.(forceRunner); ok {
+		forceRun = fr.ForceRunChan()
+	}
+
+	var tick <-chan time.Time
+	if fsw == nil {
+		ticker := time.NewTicker(delay)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	// paused reports whether the active screen (tcell) has output paused.
+	// While paused, natural ticks/events must not disturb paths' change
+	// baseline, or changes seen during the pause would be lost by the time
+	// output resumes; forceRun deliberately bypasses this.
+	paused := func() bool {
+		p, ok := screen.(interface{ Paused() bool })
+		return ok && p.Paused()
+	}
+
 	first := true
 	for {
-		time.Sleep(delay)
-		paths.update()
-		if !paths.hasChanged() {
+		run, forced := false, false
+		select {
+		case <-fsw.changedCh():
+			if paused() {
+				continue
+			}
+			run = true
+			paths.changed = fsw.Drain()
+		case <-tick:
+			if paused() {
+				continue
+			}
+			paths.update()
+			run = paths.hasChanged()
+		case <-forceRun:
+			run, forced = true, true
+		}
+		if !run {
 			continue
 		}
 
+		if ch := paths.Changed(); !forced && len(ch) > 0 {
+			cmd.env = []string{"WATCH_CHANGED=" + strings.Join(ch, " ")}
+		} else {
+			cmd.env = nil
+		}
 		cmd.run()
 
 		if err, ok := cmd.err.(*exec.ExitError); ok {
@@ -114,12 +230,20 @@ func main() {
 			bail("executing %q with args %q: %v", cmd.name, cmd.args, cmd.err)
 		} else if cmd.output() == "" {
 			screen.Status("no output")
+		} else if ch := paths.Changed(); len(ch) > 0 {
+			screen.Status("%d changed", len(ch))
 		} else {
 			screen.Status("")
 		}
 
 		if cmd.hasChanged() || first {
-			fmt.Fprint(screen, cmd.buf.String())
+			// On the first run there's no real previous output to diff
+			// against, so just print the buffer like plain/vt100 do.
+			if dw, ok := screen.(diffWriter); ok && !first {
+				dw.WriteDiff(cmd.diff())
+			} else {
+				fmt.Fprint(screen, cmd.buf.String())
+			}
 		}
 		first = false
 	}
@@ -132,24 +256,38 @@ type WatchCommand struct {
 	name    string
 	args    []string
 	timeout time.Duration
+	env     []string // extra "KEY=value" entries appended to os.Environ()
 
-	buf  bytes.Buffer
-	prev uint32
-	err  error
+	buf       bytes.Buffer
+	prevLines []string
+	prev      uint32
+	err       error
 }
 
 func (c *WatchCommand) hasChanged() bool {
 	return c.prev != adler32.Checksum(c.buf.Bytes())
 }
 
+// diff returns a line-level diff between the previous run's output and the
+// current buffer (see diffLines).
+func (c *WatchCommand) diff() []diffOp {
+	return diffLines(c.prevLines, strings.Split(c.buf.String(), "\n"))
+}
+
 func (c *WatchCommand) run() {
+	c.prevLines = strings.Split(c.buf.String(), "\n")
 	c.prev = adler32.Checksum(c.buf.Bytes())
 	c.buf.Reset()
 
+	wlog.Tracef("cmd", "running %q %q", c.name, c.args)
+
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	cmd := exec.CommandContext(ctx, c.name, c.args...)
 	cmd.Stdout = &c.buf
 	cmd.Stderr = &c.buf
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
+	}
 
 	c.err = cmd.Run()
 	if ctx.Err() != nil {
@@ -162,109 +300,222 @@ func (c *WatchCommand) output() string {
 	return strings.TrimSpace(c.buf.String())
 }
 
+// -----------------------------------------------------------------------------
+// DIFF
+
+// diffOp is one line of a line-level diff between a WatchCommand's previous
+// and current output: '=' equal, '+' added, '-' deleted.
+type diffOp struct {
+	kind rune
+	line string
+}
+
+// diffLines computes the line-level diff that turns old into new, via a
+// Hunt-McIlroy-style LCS table. O(n*m), which is fine for typical command
+// output.
+func diffLines(old, new []string) []diffOp {
+	n, m := len(old), len(new)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case old[i] == new[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case old[i] == new[j]:
+			ops = append(ops, diffOp{'=', old[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', old[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', new[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', old[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', new[j]})
+	}
+	return ops
+}
+
 // -----------------------------------------------------------------------------
 // PATHS
 
+// fileState is the subset of a regular file's metadata cheap enough to
+// check on every tick, before falling back to a content hash.
+type fileState struct {
+	size  int64
+	mtime time.Time
+	inode uint64
+	hash  uint32
+}
+
+// WatchPaths tracks the set of files selected by glob patterns (include,
+// minus exclude) and detects which of them changed between calls to
+// update: first by stat (mtime, size, inode), falling back to an Adler-32
+// content hash only for files whose stat actually changed.
 type WatchPaths struct {
-	files []string
-	dirs  []string
-	prev  uint32
-	hash  hash.Hash32
+	include []string
+	exclude []string
+	bases   []string
+
+	files   map[string]fileState
+	changed []string
 }
 
-func NewWatchPaths(pathStr string) *WatchPaths {
-	if pathStr == "" {
+// NewWatchPaths builds a WatchPaths from space separated include and
+// exclude glob patterns (see matchGlob for the pattern syntax). An empty
+// include returns nil, meaning no paths are watched: a nil WatchPaths
+// always reports hasChanged, so the command runs on every tick.
+func NewWatchPaths(include, exclude string) *WatchPaths {
+	if include == "" {
 		return nil
 	}
-	paths := strings.Fields(pathStr)
 
 	wp := &WatchPaths{
-		files: make([]string, 0),
-		dirs:  make([]string, 0),
-		hash:  adler32.New(),
+		include: strings.Fields(include),
+		exclude: strings.Fields(exclude),
+		files:   make(map[string]fileState),
 	}
-	for _, p := range paths {
-		if stat, err := os.Stat(p); err != nil {
-			bail("invalid path %q: %v", p, err)
-		} else if stat.IsDir() {
-			wp.dirs = append(wp.dirs, p)
-		} else {
-			wp.files = append(wp.files, p)
+	seen := make(map[string]bool)
+	for _, pat := range wp.include {
+		base := globBase(pat)
+		if !seen[base] {
+			seen[base] = true
+			wp.bases = append(wp.bases, base)
 		}
 	}
 	return wp
 }
 
+// matches reports whether path is selected by p's include patterns and not
+// rejected by its exclude patterns.
+func (p *WatchPaths) matches(path string) bool {
+	for _, pat := range p.exclude {
+		if matchGlob(pat, path) {
+			return false
+		}
+	}
+	for _, pat := range p.include {
+		if matchGlob(pat, path) {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *WatchPaths) hasChanged() bool {
 	if p == nil {
 		return true
 	}
-	return p.prev != p.hash.Sum32()
+	return len(p.changed) > 0
+}
+
+// Changed returns the files that changed, or were removed, during the most
+// recent update.
+func (p *WatchPaths) Changed() []string {
+	if p == nil {
+		return nil
+	}
+	return p.changed
 }
 
+// update walks p's base directories, stats every matching file and, for
+// any whose mtime/size/inode changed since the last update, hashes its
+// content to decide whether it actually changed. The result (including
+// removed files) is left in p.changed for hasChanged/Changed to report.
 func (p *WatchPaths) update() {
 	if p == nil {
 		return
 	}
 
-	p.prev = p.hash.Sum32()
-	p.hash.Reset()
+	p.changed = nil
+	seen := make(map[string]bool)
 
-	files := make(chan string, 1)
-	go func() {
-		for _, f := range p.files {
-			files <- f
-		}
-		for _, dir := range p.dirs {
-			filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
-				if err != nil {
-					bail("error walking %q: %v", path, err)
-				} else if info.Mode().IsRegular() {
-					files <- path
-				}
+	for _, base := range p.bases {
+		filepath.Walk(base, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				bail("error walking %q: %v", path, err)
+			}
+			if !info.Mode().IsRegular() || !p.matches(path) {
 				return nil
-			})
-		}
-		close(files)
-	}()
+			}
+			seen[path] = true
 
-	for f := range files {
-		if data, err := os.ReadFile(f); err != nil {
-			bail("error reading %q: %v", f, err)
-		} else {
-			p.hash.Write(data)
+			st := fileState{size: info.Size(), mtime: info.ModTime(), inode: inode(info)}
+			if old, known := p.files[path]; known && old.size == st.size && old.mtime.Equal(st.mtime) && old.inode == st.inode {
+				return nil
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				warn("reading %q: %v", path, err)
+				return nil
+			}
+			st.hash = adler32.Checksum(data)
+			if old, known := p.files[path]; !known || old.hash != st.hash {
+				wlog.Tracef("paths", "changed: %s", path)
+				p.changed = append(p.changed, path)
+			}
+			p.files[path] = st
+			return nil
+		})
+	}
+
+	for path := range p.files {
+		if !seen[path] {
+			wlog.Tracef("paths", "removed: %s", path)
+			delete(p.files, path)
+			p.changed = append(p.changed, path)
 		}
 	}
 }
 
+// inode returns info's inode number, or 0 on platforms where it isn't
+// available through syscall.Stat_t.
+func inode(info fs.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return st.Ino
+	}
+	return 0
+}
+
 // -----------------------------------------------------------------------------
 // HELPERS
 
+// bail logs msg as an error and exits with status 1.
 func bail(msg string, args ...any) {
-	txt := "ERROR: " + fmt.Sprintf(msg, args...)
-	if !strings.HasSuffix(txt, "\n") {
-		txt += "\n"
-	}
-	fmt.Fprint(os.Stderr, txt)
+	wlog.Errorf(msg, args...)
 	os.Exit(1)
 }
 
+// warn logs msg as a warning, when -v is set.
 func warn(msg string, args ...any) {
-	txt := "ERROR: " + fmt.Sprintf(msg, args...)
-	if !strings.HasSuffix(txt, "\n") {
-		txt += "\n"
-	}
-	if *verbose {
-		fmt.Fprint(os.Stderr, txt)
-	}
+	wlog.Warnf(msg, args...)
 }
 
-func debug(msg string, args ...any) {
-	txt := "DEBUG: " + fmt.Sprintf(msg, args...)
-	if !strings.HasSuffix(txt, "\n") {
-		txt += "\n"
-	}
-	if *verbose {
-		fmt.Fprint(os.Stderr, txt)
-	}
+// debug logs msg for -v, and additionally traces it under category if
+// category is enabled via WATCHTRACE.
+func debug(category, msg string, args ...any) {
+	wlog.Debugf(msg, args...)
+	wlog.Tracef(category, msg, args...)
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new []string
+		want     []diffOp
+	}{
+		{
+			name: "identical",
+			old:  []string{"a", "b"},
+			new:  []string{"a", "b"},
+			want: []diffOp{{'=', "a"}, {'=', "b"}},
+		},
+		{
+			name: "append",
+			old:  []string{"a"},
+			new:  []string{"a", "b"},
+			want: []diffOp{{'=', "a"}, {'+', "b"}},
+		},
+		{
+			name: "delete",
+			old:  []string{"a", "b"},
+			new:  []string{"a"},
+			want: []diffOp{{'=', "a"}, {'-', "b"}},
+		},
+		{
+			name: "replace middle",
+			old:  []string{"a", "b", "c"},
+			new:  []string{"a", "x", "c"},
+			want: []diffOp{{'=', "a"}, {'-', "b"}, {'+', "x"}, {'=', "c"}},
+		},
+		{
+			name: "empty old",
+			old:  nil,
+			new:  []string{"a", "b"},
+			want: []diffOp{{'+', "a"}, {'+', "b"}},
+		},
+		{
+			name: "empty new",
+			old:  []string{"a", "b"},
+			new:  nil,
+			want: []diffOp{{'-', "a"}, {'-', "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffLines(tt.old, tt.new)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines(%v, %v) = %v, want %v", tt.old, tt.new, got, tt.want)
+			}
+		})
+	}
+}